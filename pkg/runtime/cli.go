@@ -24,6 +24,9 @@ import (
 	"github.com/pkg/errors"
 )
 
+// maxFreePortAttempts bounds the retries when an auto-assigned port collides with a configured one
+const maxFreePortAttempts = 10
+
 // FromFlags parses command flags and returns DaprRuntime instance
 func FromFlags() (*DaprRuntime, error) {
 	mode := flag.String("mode", string(modes.StandaloneMode), "Runtime mode for Dapr")
@@ -75,11 +78,6 @@ func FromFlags() (*DaprRuntime, error) {
 	log.Infof("starting Dapr Runtime -- version %s -- commit %s", version.Version(), version.Commit())
 	log.Infof("log level set to: %s", loggerOptions.OutputLevel)
 
-	// Initialize dapr metrics exporter
-	if err := metricsExporter.Init(); err != nil {
-		log.Fatal(err)
-	}
-
 	daprHTTP, err := strconv.Atoi(*daprHTTPPort)
 	if err != nil {
 		return nil, errors.Wrap(err, "error parsing dapr-http-port flag")
@@ -101,11 +99,6 @@ func FromFlags() (*DaprRuntime, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "error parsing dapr-internal-grpc-port")
 		}
-	} else {
-		daprInternalGRPC, err = grpc.GetFreePort()
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get free port for internal grpc server")
-		}
 	}
 
 	var applicationPort int
@@ -116,6 +109,27 @@ func FromFlags() (*DaprRuntime, error) {
 		}
 	}
 
+	// Ports are validated before the metrics server starts listening so that a conflict
+	// is reported here rather than as a bind error from the metrics server
+	metricsOptions := metricsExporter.Options()
+	ports := portBindings(daprHTTP, daprAPIGRPC, daprInternalGRPC, applicationPort,
+		profPort, *enableProfiling, int(metricsOptions.MetricsPort()), metricsOptions.MetricsEnabled)
+	if err = validatePorts(ports); err != nil {
+		return nil, err
+	}
+
+	if daprInternalGRPC == 0 {
+		daprInternalGRPC, err = getFreePortExcluding(ports, grpc.GetFreePort)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get free port for internal grpc server")
+		}
+	}
+
+	// Initialize dapr metrics exporter
+	if err := metricsExporter.Init(); err != nil {
+		log.Fatal(err)
+	}
+
 	var maxRequestBodySize int
 	if *daprHTTPMaxRequestSize != -1 {
 		maxRequestBodySize = *daprHTTPMaxRequestSize
@@ -196,3 +210,76 @@ func parsePlacementAddr(val string) []string {
 	}
 	return parsed
 }
+
+// portBinding is a port value paired with the name of the flag it was read from
+type portBinding struct {
+	flag string
+	port int
+}
+
+// portBindings returns the ports daprd and the app are configured to listen on.
+// The internal gRPC port is only included when set explicitly (non-zero), the profile
+// and metrics ports only when those features are enabled.
+func portBindings(httpPort, apiGRPCPort, internalGRPCPort, appPort, profilePort int, enableProfiling bool, metricsPort int, metricsEnabled bool) []portBinding {
+	ports := []portBinding{
+		{flag: "dapr-http-port", port: httpPort},
+		{flag: "dapr-grpc-port", port: apiGRPCPort},
+	}
+	if internalGRPCPort != 0 {
+		ports = append(ports, portBinding{flag: "dapr-internal-grpc-port", port: internalGRPCPort})
+	}
+	ports = append(ports, portBinding{flag: "app-port", port: appPort})
+	if enableProfiling {
+		ports = append(ports, portBinding{flag: "profile-port", port: profilePort})
+	}
+	if metricsEnabled {
+		ports = append(ports, portBinding{flag: "metrics-port", port: metricsPort})
+	}
+	return ports
+}
+
+// getFreePortExcluding returns a free port from getFreePort that is not claimed by any of the given bindings
+func getFreePortExcluding(bindings []portBinding, getFreePort func() (int, error)) (int, error) {
+	claimed := map[int]bool{}
+	for _, b := range bindings {
+		claimed[b.port] = true
+	}
+
+	for i := 0; i < maxFreePortAttempts; i++ {
+		port, err := getFreePort()
+		if err != nil {
+			return 0, err
+		}
+		if !claimed[port] {
+			return port, nil
+		}
+	}
+	return 0, errors.Errorf("no free port found after %d attempts", maxFreePortAttempts)
+}
+
+// validatePorts returns an error listing every port that is claimed by more than one flag.
+// Unset ports (0) are ignored.
+func validatePorts(bindings []portBinding) error {
+	claims := map[int][]string{}
+	order := []int{}
+	for _, b := range bindings {
+		if b.port == 0 {
+			continue
+		}
+		if _, ok := claims[b.port]; !ok {
+			order = append(order, b.port)
+		}
+		claims[b.port] = append(claims[b.port], b.flag)
+	}
+
+	conflicts := []string{}
+	for _, port := range order {
+		if flags := claims[port]; len(flags) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%d is used by %s", port, strings.Join(flags, ", ")))
+		}
+	}
+	if len(conflicts) > 0 {
+		return errors.Errorf("port conflict: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
@@ -6,6 +6,7 @@
 package runtime
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,3 +37,120 @@ func TestParsePlacementAddr(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePorts(t *testing.T) {
+	t.Run("distinct ports", func(t *testing.T) {
+		err := validatePorts([]portBinding{
+			{flag: "dapr-http-port", port: 3500},
+			{flag: "dapr-grpc-port", port: 50001},
+			{flag: "app-port", port: 3000},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unset ports are ignored", func(t *testing.T) {
+		err := validatePorts([]portBinding{
+			{flag: "dapr-http-port", port: 3500},
+			{flag: "app-port", port: 0},
+			{flag: "profile-port", port: 0},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("single conflict", func(t *testing.T) {
+		err := validatePorts([]portBinding{
+			{flag: "dapr-http-port", port: 3500},
+			{flag: "dapr-grpc-port", port: 50001},
+			{flag: "app-port", port: 3500},
+		})
+		assert.EqualError(t, err, "port conflict: 3500 is used by dapr-http-port, app-port")
+	})
+
+	t.Run("multiple conflicts", func(t *testing.T) {
+		err := validatePorts([]portBinding{
+			{flag: "dapr-http-port", port: 3500},
+			{flag: "dapr-grpc-port", port: 9090},
+			{flag: "app-port", port: 3500},
+			{flag: "metrics-port", port: 9090},
+			{flag: "profile-port", port: 3500},
+		})
+		assert.EqualError(t, err, "port conflict: 3500 is used by dapr-http-port, app-port, profile-port; 9090 is used by dapr-grpc-port, metrics-port")
+	})
+}
+
+func TestPortBindings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 0, 3000, 7777, false, 9090, true)
+		assert.Equal(t, []portBinding{
+			{flag: "dapr-http-port", port: 3500},
+			{flag: "dapr-grpc-port", port: 50001},
+			{flag: "app-port", port: 3000},
+			{flag: "metrics-port", port: 9090},
+		}, ports)
+	})
+
+	t.Run("explicit internal grpc port is included", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 50002, 3000, 7777, false, 9090, false)
+		assert.Contains(t, ports, portBinding{flag: "dapr-internal-grpc-port", port: 50002})
+	})
+
+	t.Run("auto-assigned internal grpc port is excluded", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 0, 3000, 7777, false, 9090, false)
+		for _, p := range ports {
+			assert.NotEqual(t, "dapr-internal-grpc-port", p.flag)
+		}
+	})
+
+	t.Run("profile port only counted when profiling is enabled", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 0, 3000, 7777, false, 9090, false)
+		assert.NotContains(t, ports, portBinding{flag: "profile-port", port: 7777})
+
+		ports = portBindings(3500, 50001, 0, 3000, 7777, true, 9090, false)
+		assert.Contains(t, ports, portBinding{flag: "profile-port", port: 7777})
+	})
+
+	t.Run("metrics port only counted when metrics are enabled", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 0, 3000, 7777, false, 9090, false)
+		assert.NotContains(t, ports, portBinding{flag: "metrics-port", port: 9090})
+
+		ports = portBindings(3500, 50001, 0, 3000, 7777, false, 9090, true)
+		assert.Contains(t, ports, portBinding{flag: "metrics-port", port: 9090})
+	})
+
+	t.Run("disabled features do not conflict", func(t *testing.T) {
+		ports := portBindings(3500, 50001, 0, 7777, 7777, false, 3500, false)
+		assert.NoError(t, validatePorts(ports))
+	})
+}
+
+func TestGetFreePortExcluding(t *testing.T) {
+	ports := portBindings(3500, 50001, 0, 3000, 7777, false, 9090, true)
+
+	t.Run("skips configured ports", func(t *testing.T) {
+		candidates := []int{3500, 9090, 50002}
+		port, err := getFreePortExcluding(ports, func() (int, error) {
+			p := candidates[0]
+			candidates = candidates[1:]
+			return p, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 50002, port)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		attempts := 0
+		_, err := getFreePortExcluding(ports, func() (int, error) {
+			attempts++
+			return 3500, nil
+		})
+		assert.Error(t, err)
+		assert.Equal(t, maxFreePortAttempts, attempts)
+	})
+
+	t.Run("propagates errors", func(t *testing.T) {
+		_, err := getFreePortExcluding(ports, func() (int, error) {
+			return 0, errors.New("no ports")
+		})
+		assert.EqualError(t, err, "no ports")
+	})
+}